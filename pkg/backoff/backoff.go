@@ -0,0 +1,70 @@
+// Package backoff provides a small exponential-backoff retry helper used
+// in place of ad hoc busy-loop retries.
+package backoff
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Config configures an exponential backoff retry loop.
+type Config struct {
+	// InitialInterval is the delay before the first retry.
+	InitialInterval time.Duration
+	// MaxInterval caps how large the delay between retries can grow.
+	MaxInterval time.Duration
+	// Multiplier is applied to the delay after each failed attempt.
+	Multiplier float64
+}
+
+// DefaultConfig is used by Retry when given a zero-value Config.
+var DefaultConfig = Config{
+	InitialInterval: 3 * time.Second,
+	MaxInterval:     time.Minute,
+	Multiplier:      2,
+}
+
+// Retry calls fn until it returns a nil error or ctx is done, waiting an
+// exponentially increasing interval between attempts. Each failed attempt
+// is logged with its attempt number and the delay before the next one.
+func Retry(
+	ctx context.Context,
+	logger *zap.Logger,
+	config Config,
+	fn func() error,
+) {
+	if config.InitialInterval == 0 {
+		config = DefaultConfig
+	}
+
+	interval := config.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return
+		}
+
+		logger.Warn(
+			"attempt failed, retrying",
+			zap.Int("attempt", attempt),
+			zap.Duration("next_delay", interval),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		interval = time.Duration(float64(interval) * config.Multiplier)
+		if interval > config.MaxInterval {
+			interval = config.MaxInterval
+		}
+	}
+}
@@ -3,15 +3,37 @@ package beacon
 import (
 	"context"
 	"fmt"
-	"time"
 
+	"go.uber.org/zap"
+
+	"github.com/keep-network/keep-core/pkg/backoff"
 	"github.com/keep-network/keep-core/pkg/beacon/relay"
 	relaychain "github.com/keep-network/keep-core/pkg/beacon/relay/chain"
 	"github.com/keep-network/keep-core/pkg/beacon/relay/event"
+	"github.com/keep-network/keep-core/pkg/beacon/relay/quorum"
 	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/logging"
 	"github.com/keep-network/keep-core/pkg/net"
 )
 
+var logger = logging.Logger("beacon")
+
+// relayEntryQuorumDeadlineBlocks is how many blocks a relay entry request
+// is given to reach quorum on its threshold signature before the
+// aggregator gives up waiting for further shares.
+const relayEntryQuorumDeadlineBlocks = 40
+
+// relayEntrySharesChannel is the broadcast channel group members gossip
+// their relay entry signature shares on, for the quorum aggregator to
+// collect.
+const relayEntrySharesChannel = "relay-entry-shares"
+
+// relayEntrySignaturesChannel is the broadcast channel the node that
+// reconstructs a relay entry's threshold signature gossips it on, so
+// observer-only nodes can verify and re-broadcast it without having held
+// a share of their own.
+const relayEntrySignaturesChannel = "relay-entry-signatures"
+
 type participantState int
 
 const (
@@ -45,7 +67,8 @@ func Initialize(
 
 	curParticipantState, err := checkParticipantState()
 	if err != nil {
-		panic(fmt.Sprintf("Could not resolve current relay state, aborting: [%s]", err))
+		logger.Error("could not resolve current relay state, aborting", zap.Error(err))
+		return err
 	}
 
 	staker, err := stakeMonitor.StakerFor(stakingID)
@@ -54,6 +77,7 @@ func Initialize(
 	}
 
 	node := relay.NewNode(
+		logger,
 		staker,
 		netProvider,
 		blockCounter,
@@ -66,9 +90,45 @@ func Initialize(
 		return fmt.Errorf("account is unstaked")
 	default:
 		// Retry until we can sync our staking list
-		syncStakingListWithRetry(&node, relayChain)
+		syncStakingListWithRetry(ctx, &node, relayChain)
+
+		// Aggregates gossiped relay entry signature shares and submits the
+		// reconstructed threshold signature as soon as a quorum of the
+		// group has responded, instead of waiting for every member.
+		quorumAggregator := quorum.NewAggregator(
+			quorum.Threshold(chainConfig.HonestThreshold+1),
+			node.GroupCommitments(),
+			node.MessageFilter(),
+			relayChain,
+		)
+
+		if err := wireRelayEntryShareChannel(netProvider, &node, quorumAggregator); err != nil {
+			return fmt.Errorf("could not set up relay entry share channel: [%v]", err)
+		}
+
+		if err := wireRelayEntrySignatureChannel(netProvider, quorumAggregator); err != nil {
+			return fmt.Errorf("could not set up relay entry signature channel: [%v]", err)
+		}
 
 		relayChain.OnRelayEntryRequested(func(request *event.Request) {
+			requestID := request.RequestID.String()
+			requestLogger := logging.WithRequestID(logger, requestID)
+
+			if currentBlock, err := blockCounter.CurrentBlock(); err == nil {
+				quorumAggregator.TrackRequest(
+					ctx,
+					requestID,
+					blockCounter,
+					currentBlock+relayEntryQuorumDeadlineBlocks,
+				)
+			} else {
+				requestLogger.Warn(
+					"could not determine current block, skipping quorum tracking",
+					zap.Error(err),
+				)
+			}
+
+			requestLogger.Info("relay entry requested")
 			node.GenerateRelayEntryIfEligible(request, relayChain)
 		})
 
@@ -77,6 +137,9 @@ func Initialize(
 		})
 
 		relayChain.OnGroupRegistered(func(registration *event.GroupRegistration) {
+			logging.WithRequestID(logger, registration.RequestID.String()).
+				Info("group registered")
+
 			node.RegisterGroup(
 				registration.RequestID.String(),
 				registration.GroupPublicKey,
@@ -90,31 +153,116 @@ func Initialize(
 	return nil
 }
 
-func checkParticipantState() (participantState, error) {
-	return staked, nil
-}
-
-func syncStakingListWithRetry(node *relay.Node, relayChain relaychain.Interface) {
-	for {
-		t := time.NewTimer(1)
-		defer t.Stop()
-
-		select {
-		case <-t.C:
-			_, err := relayChain.GetStakerList()
-			if err != nil {
-				fmt.Printf(
-					"failed to sync staking list: [%v], retrying...\n",
-					err,
-				)
+// wireRelayEntryShareChannel joins the broadcast channel group members
+// gossip their relay entry signature shares on, registers a validator that
+// drops shares from members node's group has already filtered out, and
+// feeds every accepted share into quorumAggregator, so ReceiveShare
+// actually gets called as shares arrive instead of TrackRequest having
+// nothing to aggregate.
+func wireRelayEntryShareChannel(
+	netProvider net.Provider,
+	node *relay.Node,
+	quorumAggregator *quorum.Aggregator,
+) error {
+	shareChannel, err := netProvider.ChannelFor(
+		relayEntrySharesChannel,
+		func() net.TaggedUnmarshaler { return &quorum.ShareMessage{} },
+	)
+	if err != nil {
+		return err
+	}
 
-				// FIXME: exponential backoff
-				t.Reset(3 * time.Second)
-				continue
-			}
+	shareChannel.RegisterValidator(quorum.NewShareValidator(node.MessageFilter()))
 
-			// exit this loop when we've successfully synced
+	shareChannel.Recv(func(senderID string, message net.TaggedUnmarshaler) {
+		shareMessage, ok := message.(*quorum.ShareMessage)
+		if !ok {
 			return
 		}
+
+		if err := quorumAggregator.ReceiveShare(shareMessage.Share); err != nil {
+			logging.WithRequestID(logger, shareMessage.Share.RequestID).Warn(
+				"dropping relay entry share",
+				zap.String("member_id", senderID),
+				zap.Error(err),
+			)
+		}
+	})
+
+	return nil
+}
+
+// wireRelayEntrySignatureChannel joins the broadcast channel a relay
+// entry's reconstructed threshold signature is gossiped on, registers
+// quorumAggregator to verify and re-broadcast every signature received on
+// it, and registers quorumAggregator to publish onto the same channel the
+// signature it reconstructs itself - so observer-only nodes, which hold
+// no share of their own and therefore never call ReceiveShare, still see
+// every relay entry's signature and can serve it to other nodes.
+func wireRelayEntrySignatureChannel(
+	netProvider net.Provider,
+	quorumAggregator *quorum.Aggregator,
+) error {
+	signatureChannel, err := netProvider.ChannelFor(
+		relayEntrySignaturesChannel,
+		func() net.TaggedUnmarshaler { return &quorum.SignatureMessage{} },
+	)
+	if err != nil {
+		return err
 	}
+
+	signatureChannel.Recv(func(senderID string, message net.TaggedUnmarshaler) {
+		signatureMessage, ok := message.(*quorum.SignatureMessage)
+		if !ok {
+			return
+		}
+
+		err := quorumAggregator.VerifyAndRebroadcast(
+			signatureMessage.RequestID,
+			signatureMessage.Signature,
+			func(signature []byte) error {
+				return signatureChannel.Send(&quorum.SignatureMessage{
+					RequestID: signatureMessage.RequestID,
+					Signature: signature,
+				})
+			},
+		)
+		if err != nil {
+			logging.WithRequestID(logger, signatureMessage.RequestID).Warn(
+				"dropping gossiped aggregated signature",
+				zap.String("member_id", senderID),
+				zap.Error(err),
+			)
+		}
+	})
+
+	quorumAggregator.OnSignatureReady(func(requestID string, signature []byte) {
+		err := signatureChannel.Send(&quorum.SignatureMessage{
+			RequestID: requestID,
+			Signature: signature,
+		})
+		if err != nil {
+			logging.WithRequestID(logger, requestID).Warn(
+				"failed to broadcast aggregated signature",
+				zap.Error(err),
+			)
+		}
+	})
+
+	return nil
+}
+
+func checkParticipantState() (participantState, error) {
+	return staked, nil
+}
+
+func syncStakingListWithRetry(
+	ctx context.Context,
+	node *relay.Node,
+	relayChain relaychain.Interface,
+) {
+	backoff.Retry(ctx, logger, backoff.DefaultConfig, func() error {
+		_, err := relayChain.GetStakerList()
+		return err
+	})
 }
@@ -0,0 +1,253 @@
+// Package quorum aggregates gossiped relay entry signature shares and
+// publishes the reconstructed threshold signature as soon as a quorum of
+// group members has been heard from, instead of waiting for the full
+// round to complete.
+package quorum
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+
+	relaychain "github.com/keep-network/keep-core/pkg/beacon/relay/chain"
+	"github.com/keep-network/keep-core/pkg/beacon/relay/event"
+	"github.com/keep-network/keep-core/pkg/beacon/relay/group"
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/logging"
+)
+
+var logger = logging.Logger("keep-beacon-quorum")
+
+// Threshold is the minimum number of distinct, verified shares (t+1)
+// required to reconstruct a relay entry's threshold signature.
+type Threshold int
+
+// Share is a single group member's contribution to a relay entry's
+// threshold BLS signature.
+type Share struct {
+	MemberID  group.MemberIndex
+	RequestID string
+	Data      []byte
+}
+
+// GroupCommitments verifies shares and the signature reconstructed from
+// them against a group's public key polynomial commitments, without
+// requiring any individual member's key share to leave that member's own
+// node.
+type GroupCommitments interface {
+	// VerifyShare returns true if share was produced by memberID for
+	// requestID, per the group's public key polynomial commitments.
+	VerifyShare(memberID group.MemberIndex, requestID string, share []byte) bool
+
+	// Reconstruct combines shares into the group's threshold signature
+	// for a request.
+	Reconstruct(shares map[group.MemberIndex][]byte) ([]byte, error)
+
+	// VerifySignature returns true if signature is a valid threshold
+	// signature for requestID under the group's public key.
+	VerifySignature(requestID string, signature []byte) bool
+}
+
+// Aggregator collects gossiped signature shares for a single group and
+// submits the reconstructed threshold signature as soon as a quorum of
+// valid, non-filtered shares has been seen. It also lets observer-only
+// nodes - those holding no share of their own - verify and re-broadcast
+// an aggregated signature so that any node can serve historical relay
+// entries.
+type Aggregator struct {
+	threshold   Threshold
+	commitments GroupCommitments
+	filter      group.MessageFiltering
+	relayChain  relaychain.Interface
+
+	requestsMutex sync.Mutex
+	requests      map[string]*requestState
+	rebroadcast   map[string]bool
+
+	onSignature func(requestID string, signature []byte)
+}
+
+type requestState struct {
+	shares map[group.MemberIndex][]byte
+	done   bool
+	cancel context.CancelFunc
+}
+
+// NewAggregator creates an Aggregator for a single group, dropping shares
+// from members the group has already filtered out as inactive or
+// disqualified.
+func NewAggregator(
+	threshold Threshold,
+	commitments GroupCommitments,
+	filter group.MessageFiltering,
+	relayChain relaychain.Interface,
+) *Aggregator {
+	return &Aggregator{
+		threshold:   threshold,
+		commitments: commitments,
+		filter:      filter,
+		relayChain:  relayChain,
+		requests:    make(map[string]*requestState),
+		rebroadcast: make(map[string]bool),
+	}
+}
+
+// OnSignatureReady registers fn to be called with a request's reconstructed
+// threshold signature immediately after it has been submitted to the
+// chain, so the signature can also be gossiped for observer-only nodes to
+// verify and serve via VerifyAndRebroadcast.
+func (a *Aggregator) OnSignatureReady(fn func(requestID string, signature []byte)) {
+	a.onSignature = fn
+}
+
+// TrackRequest opens an aggregation window for requestID, scoped to a
+// context that is cancelled either when the threshold signature is
+// submitted or when deadlineBlock is reached, whichever happens first.
+func (a *Aggregator) TrackRequest(
+	ctx context.Context,
+	requestID string,
+	blockCounter chain.BlockCounter,
+	deadlineBlock int,
+) {
+	requestCtx, cancel := context.WithCancel(ctx)
+
+	a.requestsMutex.Lock()
+	a.requests[requestID] = &requestState{
+		shares: make(map[group.MemberIndex][]byte),
+		cancel: cancel,
+	}
+	a.requestsMutex.Unlock()
+
+	go a.expireOnDeadline(requestCtx, requestID, blockCounter, deadlineBlock)
+}
+
+func (a *Aggregator) expireOnDeadline(
+	ctx context.Context,
+	requestID string,
+	blockCounter chain.BlockCounter,
+	deadlineBlock int,
+) {
+	deadline, err := blockCounter.BlockHeightWaiter(deadlineBlock)
+	if err != nil {
+		logging.WithRequestID(logger, requestID).Warn(
+			"could not watch for request deadline", zap.Error(err),
+		)
+		return
+	}
+
+	select {
+	case <-deadline:
+		a.expire(requestID)
+	case <-ctx.Done():
+	}
+}
+
+func (a *Aggregator) expire(requestID string) {
+	a.requestsMutex.Lock()
+	defer a.requestsMutex.Unlock()
+
+	if state, exists := a.requests[requestID]; exists && !state.done {
+		state.cancel()
+		delete(a.requests, requestID)
+	}
+}
+
+// ReceiveShare verifies a gossiped Share against the group's public key
+// commitments and, once a quorum of distinct valid shares has been seen
+// for its request, reconstructs and submits the threshold signature
+// without waiting for the rest of the group to respond.
+func (a *Aggregator) ReceiveShare(share *Share) error {
+	if !a.filter.IsSenderAccepted(share.MemberID) {
+		return fmt.Errorf(
+			"dropping share from filtered-out member [%v]",
+			share.MemberID,
+		)
+	}
+
+	if !a.commitments.VerifyShare(share.MemberID, share.RequestID, share.Data) {
+		return fmt.Errorf(
+			"share from member [%v] failed commitment verification",
+			share.MemberID,
+		)
+	}
+
+	a.requestsMutex.Lock()
+	state, exists := a.requests[share.RequestID]
+	if !exists || state.done {
+		a.requestsMutex.Unlock()
+		return nil
+	}
+	state.shares[share.MemberID] = share.Data
+	reachedThreshold := len(state.shares) >= int(a.threshold)
+	a.requestsMutex.Unlock()
+
+	if !reachedThreshold {
+		return nil
+	}
+
+	return a.finalize(share.RequestID, state)
+}
+
+func (a *Aggregator) finalize(requestID string, state *requestState) error {
+	a.requestsMutex.Lock()
+	if state.done {
+		a.requestsMutex.Unlock()
+		return nil
+	}
+	state.done = true
+	shares := state.shares
+	a.requestsMutex.Unlock()
+
+	state.cancel()
+
+	signature, err := a.commitments.Reconstruct(shares)
+	if err != nil {
+		return fmt.Errorf(
+			"failed to reconstruct threshold signature for request [%s]: [%v]",
+			requestID,
+			err,
+		)
+	}
+
+	if err := a.relayChain.SubmitRelayEntry(&event.Entry{Value: signature}); err != nil {
+		return err
+	}
+
+	if a.onSignature != nil {
+		a.onSignature(requestID, signature)
+	}
+
+	return nil
+}
+
+// VerifyAndRebroadcast lets an observer-only node - one holding no share
+// of its own - verify a threshold signature gossiped for requestID and
+// re-broadcast it via rebroadcast, so any node can serve historical relay
+// entries. It rebroadcasts at most once per requestID: rebroadcast always
+// publishes under a fresh envelope, which the receiving channel's
+// (senderID, sequence) dedup cannot recognize as a repeat, so without this
+// guard every node would re-publish every signature it receives forever.
+func (a *Aggregator) VerifyAndRebroadcast(
+	requestID string,
+	signature []byte,
+	rebroadcast func(signature []byte) error,
+) error {
+	if !a.commitments.VerifySignature(requestID, signature) {
+		return fmt.Errorf(
+			"aggregated signature for request [%s] failed verification",
+			requestID,
+		)
+	}
+
+	a.requestsMutex.Lock()
+	if a.rebroadcast[requestID] {
+		a.requestsMutex.Unlock()
+		return nil
+	}
+	a.rebroadcast[requestID] = true
+	a.requestsMutex.Unlock()
+
+	return rebroadcast(signature)
+}
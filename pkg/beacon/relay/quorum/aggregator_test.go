@@ -0,0 +1,331 @@
+package quorum
+
+import (
+	"fmt"
+	"testing"
+
+	relaychain "github.com/keep-network/keep-core/pkg/beacon/relay/chain"
+	"github.com/keep-network/keep-core/pkg/beacon/relay/event"
+	"github.com/keep-network/keep-core/pkg/beacon/relay/group"
+)
+
+// stubCommitments is a GroupCommitments fake whose VerifyShare/Reconstruct
+// results are controlled directly by the test.
+type stubCommitments struct {
+	rejectedMembers map[group.MemberIndex]bool
+	reconstructed   []byte
+	reconstructErr  error
+}
+
+func (s *stubCommitments) VerifyShare(memberID group.MemberIndex, requestID string, share []byte) bool {
+	return !s.rejectedMembers[memberID]
+}
+
+func (s *stubCommitments) Reconstruct(shares map[group.MemberIndex][]byte) ([]byte, error) {
+	if s.reconstructErr != nil {
+		return nil, s.reconstructErr
+	}
+	return s.reconstructed, nil
+}
+
+type stubCommitmentsWithSignatureVerdict struct {
+	stubCommitments
+	verifySignatureResult bool
+}
+
+func (s *stubCommitmentsWithSignatureVerdict) VerifySignature(requestID string, signature []byte) bool {
+	return s.verifySignatureResult
+}
+
+func (s *stubCommitments) VerifySignature(requestID string, signature []byte) bool {
+	return true
+}
+
+// stubFilter is a group.MessageFiltering fake that rejects a fixed set of
+// members, standing in for members already marked inactive/disqualified.
+type stubFilter struct {
+	rejectedMembers map[group.MemberIndex]bool
+}
+
+func (s *stubFilter) IsSenderAccepted(senderID group.MemberIndex) bool {
+	return !s.rejectedMembers[senderID]
+}
+
+// stubRelayChain is a minimal relaychain.Interface fake covering only the
+// methods Aggregator itself calls - SubmitRelayEntry, to observe the
+// reconstructed signature once quorum is reached. The embedded interface
+// supplies the remaining methods; calling any of them panics.
+type stubRelayChain struct {
+	relaychain.Interface
+
+	submitted      []byte
+	submitCalled   bool
+	submitEntryErr error
+}
+
+func (s *stubRelayChain) SubmitRelayEntry(entry *event.Entry) error {
+	s.submitCalled = true
+	s.submitted = entry.Value
+	return s.submitEntryErr
+}
+
+func newTestRequest(t *testing.T, threshold Threshold, commitments *stubCommitments, filter *stubFilter, relayChain *stubRelayChain) (*Aggregator, string) {
+	t.Helper()
+
+	aggregator := &Aggregator{
+		threshold:   threshold,
+		commitments: commitments,
+		filter:      filter,
+		relayChain:  relayChain,
+		requests:    make(map[string]*requestState),
+		rebroadcast: make(map[string]bool),
+	}
+
+	requestID := "request-1"
+	aggregator.requests[requestID] = &requestState{
+		shares: make(map[group.MemberIndex][]byte),
+		cancel: func() {},
+	}
+
+	return aggregator, requestID
+}
+
+func TestReceiveShareRejectsFilteredMember(t *testing.T) {
+	commitments := &stubCommitments{rejectedMembers: map[group.MemberIndex]bool{}}
+	filter := &stubFilter{rejectedMembers: map[group.MemberIndex]bool{3: true}}
+	relayChain := &stubRelayChain{}
+
+	aggregator, requestID := newTestRequest(t, 2, commitments, filter, relayChain)
+
+	err := aggregator.ReceiveShare(&Share{MemberID: 3, RequestID: requestID, Data: []byte("share")})
+	if err == nil {
+		t.Fatal("expected an error for a filtered-out member")
+	}
+	if relayChain.submitCalled {
+		t.Error("did not expect a relay entry submission for a rejected share")
+	}
+}
+
+func TestReceiveShareRejectsInvalidCommitment(t *testing.T) {
+	commitments := &stubCommitments{rejectedMembers: map[group.MemberIndex]bool{5: true}}
+	filter := &stubFilter{rejectedMembers: map[group.MemberIndex]bool{}}
+	relayChain := &stubRelayChain{}
+
+	aggregator, requestID := newTestRequest(t, 2, commitments, filter, relayChain)
+
+	err := aggregator.ReceiveShare(&Share{MemberID: 5, RequestID: requestID, Data: []byte("share")})
+	if err == nil {
+		t.Fatal("expected an error for a share that fails commitment verification")
+	}
+}
+
+func TestReceiveShareDoesNotFinalizeBelowThreshold(t *testing.T) {
+	commitments := &stubCommitments{rejectedMembers: map[group.MemberIndex]bool{}}
+	filter := &stubFilter{rejectedMembers: map[group.MemberIndex]bool{}}
+	relayChain := &stubRelayChain{}
+
+	aggregator, requestID := newTestRequest(t, 3, commitments, filter, relayChain)
+
+	for _, memberID := range []group.MemberIndex{1, 2} {
+		if err := aggregator.ReceiveShare(&Share{
+			MemberID:  memberID,
+			RequestID: requestID,
+			Data:      []byte("share"),
+		}); err != nil {
+			t.Fatalf("unexpected error for member %d: %v", memberID, err)
+		}
+	}
+
+	if relayChain.submitCalled {
+		t.Error("did not expect a relay entry submission before threshold was reached")
+	}
+}
+
+func TestReceiveShareFinalizesOnceThresholdReached(t *testing.T) {
+	commitments := &stubCommitments{
+		rejectedMembers: map[group.MemberIndex]bool{},
+		reconstructed:   []byte("reconstructed-signature"),
+	}
+	filter := &stubFilter{rejectedMembers: map[group.MemberIndex]bool{}}
+	relayChain := &stubRelayChain{}
+
+	aggregator, requestID := newTestRequest(t, 2, commitments, filter, relayChain)
+
+	for _, memberID := range []group.MemberIndex{1, 2, 3} {
+		if err := aggregator.ReceiveShare(&Share{
+			MemberID:  memberID,
+			RequestID: requestID,
+			Data:      []byte("share"),
+		}); err != nil {
+			t.Fatalf("unexpected error for member %d: %v", memberID, err)
+		}
+	}
+
+	if !relayChain.submitCalled {
+		t.Fatal("expected a relay entry submission once threshold was reached")
+	}
+	if string(relayChain.submitted) != "reconstructed-signature" {
+		t.Errorf("submitted = %q, want %q", relayChain.submitted, "reconstructed-signature")
+	}
+
+	// A late share for the same, already-finalized request must not submit
+	// a second time.
+	relayChain.submitCalled = false
+	if err := aggregator.ReceiveShare(&Share{MemberID: 4, RequestID: requestID, Data: []byte("late")}); err != nil {
+		t.Fatalf("unexpected error for late share: %v", err)
+	}
+	if relayChain.submitCalled {
+		t.Error("did not expect a second relay entry submission for a late share")
+	}
+}
+
+func TestReceiveShareIgnoresUntrackedRequest(t *testing.T) {
+	commitments := &stubCommitments{rejectedMembers: map[group.MemberIndex]bool{}}
+	filter := &stubFilter{rejectedMembers: map[group.MemberIndex]bool{}}
+	relayChain := &stubRelayChain{}
+
+	aggregator, _ := newTestRequest(t, 2, commitments, filter, relayChain)
+
+	err := aggregator.ReceiveShare(&Share{MemberID: 1, RequestID: "no-such-request", Data: []byte("share")})
+	if err != nil {
+		t.Fatalf("unexpected error for an untracked request: %v", err)
+	}
+	if relayChain.submitCalled {
+		t.Error("did not expect a relay entry submission for an untracked request")
+	}
+}
+
+func TestReceiveShareNotifiesOnSignatureReady(t *testing.T) {
+	commitments := &stubCommitments{
+		rejectedMembers: map[group.MemberIndex]bool{},
+		reconstructed:   []byte("reconstructed-signature"),
+	}
+	filter := &stubFilter{rejectedMembers: map[group.MemberIndex]bool{}}
+	relayChain := &stubRelayChain{}
+
+	aggregator, requestID := newTestRequest(t, 2, commitments, filter, relayChain)
+
+	var notifiedRequestID string
+	var notifiedSignature []byte
+	aggregator.OnSignatureReady(func(requestID string, signature []byte) {
+		notifiedRequestID = requestID
+		notifiedSignature = signature
+	})
+
+	for _, memberID := range []group.MemberIndex{1, 2} {
+		if err := aggregator.ReceiveShare(&Share{
+			MemberID:  memberID,
+			RequestID: requestID,
+			Data:      []byte("share"),
+		}); err != nil {
+			t.Fatalf("unexpected error for member %d: %v", memberID, err)
+		}
+	}
+
+	if notifiedRequestID != requestID {
+		t.Errorf("notified request ID = %q, want %q", notifiedRequestID, requestID)
+	}
+	if string(notifiedSignature) != "reconstructed-signature" {
+		t.Errorf("notified signature = %q, want %q", notifiedSignature, "reconstructed-signature")
+	}
+}
+
+func TestReceiveShareDoesNotNotifyOnFailedSubmission(t *testing.T) {
+	commitments := &stubCommitments{
+		rejectedMembers: map[group.MemberIndex]bool{},
+		reconstructed:   []byte("reconstructed-signature"),
+	}
+	filter := &stubFilter{rejectedMembers: map[group.MemberIndex]bool{}}
+	relayChain := &stubRelayChain{submitEntryErr: fmt.Errorf("submission rejected")}
+
+	aggregator, requestID := newTestRequest(t, 2, commitments, filter, relayChain)
+
+	notified := false
+	aggregator.OnSignatureReady(func(requestID string, signature []byte) {
+		notified = true
+	})
+
+	for _, memberID := range []group.MemberIndex{1, 2} {
+		err := aggregator.ReceiveShare(&Share{
+			MemberID:  memberID,
+			RequestID: requestID,
+			Data:      []byte("share"),
+		})
+		if memberID == 2 && err == nil {
+			t.Fatal("expected the submission failure to surface as an error")
+		}
+	}
+
+	if notified {
+		t.Error("did not expect onSignature to fire after a failed submission")
+	}
+}
+
+func TestVerifyAndRebroadcastRejectsInvalidSignature(t *testing.T) {
+	commitments := &stubCommitmentsWithSignatureVerdict{verifySignatureResult: false}
+	aggregator := &Aggregator{
+		commitments: commitments,
+		requests:    make(map[string]*requestState),
+		rebroadcast: make(map[string]bool),
+	}
+
+	rebroadcastCalled := false
+	err := aggregator.VerifyAndRebroadcast("request-1", []byte("signature"), func(signature []byte) error {
+		rebroadcastCalled = true
+		return nil
+	})
+
+	if err == nil {
+		t.Fatal("expected an error for a signature that fails verification")
+	}
+	if rebroadcastCalled {
+		t.Error("did not expect a rebroadcast for an invalid signature")
+	}
+}
+
+func TestVerifyAndRebroadcastRebroadcastsValidSignature(t *testing.T) {
+	commitments := &stubCommitmentsWithSignatureVerdict{verifySignatureResult: true}
+	aggregator := &Aggregator{
+		commitments: commitments,
+		requests:    make(map[string]*requestState),
+		rebroadcast: make(map[string]bool),
+	}
+
+	var rebroadcastSignature []byte
+	err := aggregator.VerifyAndRebroadcast("request-1", []byte("signature"), func(signature []byte) error {
+		rebroadcastSignature = signature
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error for a valid signature: %v", err)
+	}
+	if string(rebroadcastSignature) != "signature" {
+		t.Errorf("rebroadcast signature = %q, want %q", rebroadcastSignature, "signature")
+	}
+}
+
+func TestVerifyAndRebroadcastOnlyRebroadcastsOncePerRequest(t *testing.T) {
+	commitments := &stubCommitmentsWithSignatureVerdict{verifySignatureResult: true}
+	aggregator := &Aggregator{
+		commitments: commitments,
+		requests:    make(map[string]*requestState),
+		rebroadcast: make(map[string]bool),
+	}
+
+	rebroadcastCount := 0
+	rebroadcastFn := func(signature []byte) error {
+		rebroadcastCount++
+		return nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := aggregator.VerifyAndRebroadcast("request-1", []byte("signature"), rebroadcastFn); err != nil {
+			t.Fatalf("unexpected error on attempt %d: %v", i, err)
+		}
+	}
+
+	if rebroadcastCount != 1 {
+		t.Errorf("rebroadcast count = %d, want 1", rebroadcastCount)
+	}
+}
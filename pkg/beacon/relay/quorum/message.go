@@ -0,0 +1,95 @@
+package quorum
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/keep-network/keep-core/pkg/beacon/relay/group"
+)
+
+// relayEntryShareMessageType is this message's tag on the wire, so a
+// receiver's channel can route it to the right net.TaggedUnmarshaler.
+const relayEntryShareMessageType = "quorum/relay_entry_share"
+
+// ShareMessage is the gossiped wire message carrying a single Share, so it
+// can travel over a net.BroadcastChannel and be fed into an Aggregator's
+// ReceiveShare on arrival.
+type ShareMessage struct {
+	Share *Share
+}
+
+// Type returns this message's wire type tag.
+func (m *ShareMessage) Type() string {
+	return relayEntryShareMessageType
+}
+
+// Marshal serializes the message for publication on a broadcast channel.
+func (m *ShareMessage) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+		return nil, fmt.Errorf("failed to marshal share message: [%v]", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal deserializes bytes received from a broadcast channel back into
+// the message.
+func (m *ShareMessage) Unmarshal(payload []byte) error {
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(m); err != nil {
+		return fmt.Errorf("failed to unmarshal share message: [%v]", err)
+	}
+	return nil
+}
+
+// relayEntrySignatureMessageType is this message's tag on the wire, so a
+// receiver's channel can route it to the right net.TaggedUnmarshaler.
+const relayEntrySignatureMessageType = "quorum/relay_entry_signature"
+
+// SignatureMessage is the gossiped wire message carrying a reconstructed
+// threshold signature for a relay entry, so an observer-only node - one
+// holding no share of its own - can verify it against the group's public
+// key and re-broadcast it via an Aggregator's VerifyAndRebroadcast.
+type SignatureMessage struct {
+	RequestID string
+	Signature []byte
+}
+
+// Type returns this message's wire type tag.
+func (m *SignatureMessage) Type() string {
+	return relayEntrySignatureMessageType
+}
+
+// Marshal serializes the message for publication on a broadcast channel.
+func (m *SignatureMessage) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+		return nil, fmt.Errorf("failed to marshal signature message: [%v]", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal deserializes bytes received from a broadcast channel back into
+// the message.
+func (m *SignatureMessage) Unmarshal(payload []byte) error {
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(m); err != nil {
+		return fmt.Errorf("failed to unmarshal signature message: [%v]", err)
+	}
+	return nil
+}
+
+// NewShareValidator returns a pubsub validator that rejects gossiped shares
+// from members filter has already marked as inactive or disqualified, so
+// they are dropped at the channel layer instead of inside the aggregator.
+// It is meant to be registered, via RegisterValidator, on the channel used
+// to exchange ShareMessages for filter's group.
+func NewShareValidator(filter group.MessageFiltering) func(senderID string, payload []byte) bool {
+	return func(senderID string, payload []byte) bool {
+		message := &ShareMessage{}
+		if err := message.Unmarshal(payload); err != nil {
+			return false
+		}
+
+		return filter.IsSenderAccepted(message.Share.MemberID)
+	}
+}
@@ -0,0 +1,346 @@
+package libp2p
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	host "github.com/libp2p/go-libp2p-host"
+	peer "github.com/libp2p/go-libp2p-peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+
+	"go.uber.org/zap"
+
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/logging"
+	"github.com/keep-network/keep-core/pkg/net"
+	"github.com/keep-network/keep-core/pkg/net/key"
+)
+
+// DefaultEnvelopeFreshness is how old an incoming envelope is allowed to be
+// before it is rejected as stale.
+const DefaultEnvelopeFreshness = 5 * time.Minute
+
+// DefaultValidatorWorkerCount is the number of goroutines each topic's
+// validatorPool runs its validate function on.
+const DefaultValidatorWorkerCount = 4
+
+// channelManager creates and tracks gossipsub-backed broadcast channels for
+// this node, replacing the previous bespoke pub/sub implementation with
+// go-libp2p-pubsub. It signs every outbound envelope with the node's static
+// key and, per topic, runs a validator that authenticates, checks stake,
+// checks freshness, and deduplicates incoming envelopes before handing them
+// to a channel's subscribers.
+type channelManager struct {
+	ctx context.Context
+
+	identity *identity
+	staker   *key.NetworkPrivate
+	ps       *pubsub.PubSub
+
+	stakeMonitor chain.StakeMonitor
+	freshness    time.Duration
+
+	channelsMutex sync.Mutex
+	channels      map[string]*gossipChannel
+
+	sequence uint64
+}
+
+func newChannelManager(
+	ctx context.Context,
+	identity *identity,
+	h host.Host,
+	stakeMonitor chain.StakeMonitor,
+) (*channelManager, error) {
+	ps, err := pubsub.NewGossipSub(ctx, h)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize gossipsub: [%v]", err)
+	}
+
+	return &channelManager{
+		ctx:          ctx,
+		identity:     identity,
+		staker:       identity.privKey,
+		ps:           ps,
+		stakeMonitor: stakeMonitor,
+		freshness:    DefaultEnvelopeFreshness,
+		channels:     make(map[string]*gossipChannel),
+	}, nil
+}
+
+// getChannel returns the gossipsub-backed channel for name, joining its
+// topic and subscribing to it the first time it is requested.
+func (cm *channelManager) getChannel(name string) (*gossipChannel, error) {
+	cm.channelsMutex.Lock()
+	defer cm.channelsMutex.Unlock()
+
+	channel, exists := cm.channels[name]
+	if exists {
+		return channel, nil
+	}
+
+	topic, err := cm.ps.Join(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not join topic [%s]: [%v]", name, err)
+	}
+
+	subscription, err := topic.Subscribe()
+	if err != nil {
+		return nil, fmt.Errorf("could not subscribe to topic [%s]: [%v]", name, err)
+	}
+
+	channel = &gossipChannel{
+		name:          name,
+		manager:       cm,
+		topic:         topic,
+		subscription:  subscription,
+		seenEnvelopes: make(map[seenKey]time.Time),
+	}
+
+	go channel.handleMessages(cm.ctx)
+	go channel.pruneSeenEnvelopes(cm.ctx)
+
+	cm.channels[name] = channel
+
+	return channel, nil
+}
+
+// seenKey identifies an envelope by its sender and sequence number for the
+// purpose of deduplicating re-gossiped traffic.
+type seenKey struct {
+	senderID peer.ID
+	sequence uint64
+}
+
+// gossipChannel is a net.BroadcastChannel backed by a single gossipsub
+// topic and subscription.
+type gossipChannel struct {
+	name string
+
+	manager      *channelManager
+	topic        *pubsub.Topic
+	subscription *pubsub.Subscription
+
+	validatorMutex sync.Mutex
+	validatorPool  *validatorPool
+
+	handlersMutex sync.Mutex
+	handlers      []net.HandleMessageFunc
+
+	unmarshalerType func() net.TaggedUnmarshaler
+
+	seenMutex     sync.Mutex
+	seenEnvelopes map[seenKey]time.Time
+}
+
+func (gc *gossipChannel) Name() string {
+	return gc.name
+}
+
+// RegisterUnmarshaler sets the constructor used to decode the payload of
+// every envelope accepted on this channel before it reaches a handler
+// registered with Recv.
+func (gc *gossipChannel) RegisterUnmarshaler(unmarshaler func() net.TaggedUnmarshaler) error {
+	gc.unmarshalerType = unmarshaler
+	return nil
+}
+
+// RegisterValidator installs fn as the validation function run against
+// every envelope's payload received on this channel's topic, behind a
+// bounded worker pool so a slow validator cannot stall the shared pubsub
+// read loop.
+func (gc *gossipChannel) RegisterValidator(fn func(senderID string, payload []byte) bool) {
+	gc.validatorMutex.Lock()
+	defer gc.validatorMutex.Unlock()
+
+	if gc.validatorPool != nil {
+		gc.validatorPool.close()
+	}
+
+	gc.validatorPool = newValidatorPool(
+		fn,
+		DefaultValidatorWorkerCount,
+		DefaultValidatorQueueSize,
+	)
+}
+
+// Send signs message in an envelope stamped with the current sequence
+// number and publishes it to this channel's topic.
+func (gc *gossipChannel) Send(message net.TaggedMarshaler) error {
+	payload, err := message.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: [%v]", err)
+	}
+
+	sequence := atomic.AddUint64(&gc.manager.sequence, 1)
+
+	env, err := newSignedEnvelope(
+		gc.manager.identity.id,
+		sequence,
+		payload,
+		gc.manager.staker,
+	)
+	if err != nil {
+		return err
+	}
+
+	data, err := env.marshal()
+	if err != nil {
+		return err
+	}
+
+	return gc.topic.Publish(gc.manager.ctx, data)
+}
+
+// handleMessages is the channel's pubsub read loop: it decodes each
+// message into an envelope and runs it through the manager's default
+// authentication checks (signature, freshness, stake, dedup). Accepted
+// envelopes are handed off to the channel's validator pool (if one is
+// registered) for the topic-specific check, which dispatches the envelope
+// itself once it has a verdict - the read loop never waits for that
+// verdict, so a slow or adversarial validator can only ever stall its own
+// topic, never this loop's ability to keep reading.
+func (gc *gossipChannel) handleMessages(ctx context.Context) {
+	channelLogger := logger.With(zap.String("channel", gc.name))
+
+	for {
+		msg, err := gc.subscription.Next(ctx)
+		if err != nil {
+			channelLogger.Error("gossip channel read loop exiting", zap.Error(err))
+			return
+		}
+
+		env, err := unmarshalEnvelope(msg.Data)
+		if err != nil {
+			channelLogger.Warn("dropping malformed envelope", zap.Error(err))
+			continue
+		}
+
+		if !gc.acceptDefaults(env) {
+			continue
+		}
+
+		gc.validatorMutex.Lock()
+		pool := gc.validatorPool
+		gc.validatorMutex.Unlock()
+
+		if pool == nil {
+			gc.dispatch(env)
+			continue
+		}
+
+		pool.submit(env.SenderID.String(), env.Payload, func(accepted bool) {
+			if accepted {
+				gc.dispatch(env)
+			}
+		})
+	}
+}
+
+// acceptDefaults runs the default sender-authentication, stake, freshness,
+// and dedup checks every envelope on every channel must pass, regardless of
+// whether a topic-specific validator is also registered.
+func (gc *gossipChannel) acceptDefaults(env *envelope) bool {
+	envLogger := logging.WithPeerID(logger, env.SenderID.String())
+
+	senderPublicKey, err := gc.manager.identity.peerPublicKey(env.SenderID)
+	if err != nil {
+		envLogger.Warn("dropping envelope from unknown peer", zap.Error(err))
+		return false
+	}
+
+	if err := env.verifySignature(senderPublicKey); err != nil {
+		envLogger.Warn("dropping envelope", zap.Error(err))
+		return false
+	}
+
+	if err := env.verifyFreshness(gc.manager.freshness); err != nil {
+		envLogger.Warn("dropping envelope", zap.Error(err))
+		return false
+	}
+
+	hasMinimumStake, err := gc.manager.stakeMonitor.HasMinimumStake(senderPublicKey.Address())
+	if err != nil || !hasMinimumStake {
+		envLogger.Warn("dropping envelope from unstaked peer")
+		return false
+	}
+
+	dedupKey := seenKey{senderID: env.SenderID, sequence: env.Sequence}
+	gc.seenMutex.Lock()
+	_, alreadySeen := gc.seenEnvelopes[dedupKey]
+	gc.seenEnvelopes[dedupKey] = time.Now()
+	gc.seenMutex.Unlock()
+
+	return !alreadySeen
+}
+
+// pruneSeenEnvelopes periodically evicts seenEnvelopes entries last seen
+// longer ago than this channel's freshness window plus maxClockSkew. An
+// envelope received that long ago would already be rejected by
+// verifyFreshness before ever reaching the dedup check, even one stamped
+// maxClockSkew ahead of the local clock, so it can never be legitimately
+// re-seen - without this, seenEnvelopes would grow without bound for the
+// life of the node.
+func (gc *gossipChannel) pruneSeenEnvelopes(ctx context.Context) {
+	ticker := time.NewTicker(gc.manager.freshness)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cutoff := time.Now().Add(-(gc.manager.freshness + maxClockSkew))
+
+			gc.seenMutex.Lock()
+			for key, lastSeen := range gc.seenEnvelopes {
+				if lastSeen.Before(cutoff) {
+					delete(gc.seenEnvelopes, key)
+				}
+			}
+			gc.seenMutex.Unlock()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (gc *gossipChannel) dispatch(env *envelope) {
+	if gc.unmarshalerType == nil {
+		logger.Warn(
+			"dropping message: no unmarshaler registered",
+			zap.String("channel", gc.name),
+		)
+		return
+	}
+
+	message := gc.unmarshalerType()
+	if err := message.Unmarshal(env.Payload); err != nil {
+		logger.Warn(
+			"dropping malformed message",
+			zap.String("channel", gc.name),
+			zap.Error(err),
+		)
+		return
+	}
+
+	gc.handlersMutex.Lock()
+	handlers := make([]net.HandleMessageFunc, len(gc.handlers))
+	copy(handlers, gc.handlers)
+	gc.handlersMutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(env.SenderID.String(), message)
+	}
+}
+
+// Recv registers handler to be invoked with the sender ID and the decoded
+// message for every envelope accepted on this channel.
+func (gc *gossipChannel) Recv(handler net.HandleMessageFunc) error {
+	gc.handlersMutex.Lock()
+	defer gc.handlersMutex.Unlock()
+
+	gc.handlers = append(gc.handlers, handler)
+	return nil
+}
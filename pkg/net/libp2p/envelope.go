@@ -0,0 +1,131 @@
+package libp2p
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"time"
+
+	peer "github.com/libp2p/go-libp2p-peer"
+
+	"github.com/keep-network/keep-core/pkg/net/key"
+)
+
+// envelope is the wire format published to every gossipsub topic. Wrapping
+// each outbound message this way lets a topic's validator authenticate and
+// deduplicate traffic before it is ever handed to ChannelFor's recipients,
+// instead of every DKG phase handler having to do it itself.
+type envelope struct {
+	SenderID  peer.ID
+	Sequence  uint64
+	Timestamp int64
+	Payload   []byte
+	Signature []byte
+}
+
+// signingContent returns the bytes that are signed and later verified for
+// an envelope. The signature field itself is never part of its own content.
+func (e *envelope) signingContent() []byte {
+	content := make([]byte, 0, len(e.SenderID)+16+len(e.Payload))
+	content = append(content, []byte(e.SenderID)...)
+
+	seqBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(seqBytes, e.Sequence)
+	content = append(content, seqBytes...)
+
+	tsBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(tsBytes, uint64(e.Timestamp))
+	content = append(content, tsBytes...)
+
+	return append(content, e.Payload...)
+}
+
+// newSignedEnvelope stamps payload with senderID, the next sequence number
+// for that sender, and the current time, and signs the result with
+// staticKey so that receivers can authenticate it came from senderID.
+func newSignedEnvelope(
+	senderID peer.ID,
+	sequence uint64,
+	payload []byte,
+	staticKey *key.NetworkPrivate,
+) (*envelope, error) {
+	e := &envelope{
+		SenderID:  senderID,
+		Sequence:  sequence,
+		Timestamp: time.Now().Unix(),
+		Payload:   payload,
+	}
+
+	signature, err := staticKey.Sign(e.signingContent())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign outbound envelope: [%v]", err)
+	}
+	e.Signature = signature
+
+	return e, nil
+}
+
+// verifySignature checks the envelope's signature against senderPublicKey.
+func (e *envelope) verifySignature(senderPublicKey *key.NetworkPublic) error {
+	if !senderPublicKey.Verify(e.signingContent(), e.Signature) {
+		return fmt.Errorf(
+			"signature verification failed for envelope from [%v]",
+			e.SenderID,
+		)
+	}
+
+	return nil
+}
+
+// maxClockSkew is how far ahead of the receiver's own clock an envelope's
+// timestamp is allowed to be before it is rejected as forged; legitimate
+// senders are expected to be within ordinary NTP drift of the receiver.
+const maxClockSkew = 30 * time.Second
+
+// verifyFreshness rejects envelopes stamped further in the past than
+// maxAge, which is what stops a replayed envelope from an earlier session
+// being accepted as current, and envelopes stamped further in the future
+// than maxClockSkew, which stops a sender from forging a future timestamp
+// to bypass the freshness window indefinitely.
+func (e *envelope) verifyFreshness(maxAge time.Duration) error {
+	age := time.Since(time.Unix(e.Timestamp, 0))
+	if age > maxAge {
+		return fmt.Errorf(
+			"envelope from [%v] is [%v] old, exceeds freshness window [%v]",
+			e.SenderID,
+			age,
+			maxAge,
+		)
+	}
+
+	if age < -maxClockSkew {
+		return fmt.Errorf(
+			"envelope from [%v] is stamped [%v] ahead of the local clock, exceeds allowed skew [%v]",
+			e.SenderID,
+			-age,
+			maxClockSkew,
+		)
+	}
+
+	return nil
+}
+
+// marshal serializes the envelope for publication on a pubsub topic.
+func (e *envelope) marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(e); err != nil {
+		return nil, fmt.Errorf("failed to marshal envelope: [%v]", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalEnvelope deserializes bytes received from a pubsub topic back
+// into an envelope.
+func unmarshalEnvelope(data []byte) (*envelope, error) {
+	e := &envelope{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(e); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal envelope: [%v]", err)
+	}
+	return e, nil
+}
@@ -6,15 +6,18 @@ import (
 	"sync"
 	"time"
 
-	"github.com/ipfs/go-log"
+	"go.uber.org/zap"
 
 	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/logging"
 	"github.com/keep-network/keep-core/pkg/net"
 	"github.com/keep-network/keep-core/pkg/net/key"
+	"github.com/keep-network/keep-core/pkg/net/peermgr"
 	"github.com/keep-network/keep-core/pkg/net/watchtower"
 
 	dstore "github.com/ipfs/go-datastore"
 	dssync "github.com/ipfs/go-datastore/sync"
+	dsleveldb "github.com/ipfs/go-ds-leveldb"
 	addrutil "github.com/libp2p/go-addr-util"
 	libp2p "github.com/libp2p/go-libp2p"
 	connmgr "github.com/libp2p/go-libp2p-connmgr"
@@ -25,11 +28,10 @@ import (
 	peerstore "github.com/libp2p/go-libp2p-peerstore"
 	rhost "github.com/libp2p/go-libp2p/p2p/host/routed"
 
-	bootstrap "github.com/keep-network/go-libp2p-bootstrap"
 	ma "github.com/multiformats/go-multiaddr"
 )
 
-var logger = log.Logger("keep-net-libp2p")
+var logger = logging.Logger("keep-net-libp2p")
 
 // Defaults from ipfs
 const (
@@ -46,22 +48,22 @@ const (
 	DefaultConnMgrGracePeriod = time.Second * 20
 )
 
-// watchtower constants
-const (
-	// StakeCheckTick is the amount of time between periodic checks for
-	// minimum stake for all peers connected to this one.
-	StakeCheckTick = time.Minute * 1
-	// BootstrapCheckPeriod is the amount of time between periodic checks
-	// for ensuring we are connected to an appropriate number of bootstrap
-	// peers.
-	BootstrapCheckPeriod = 10 * time.Second
-)
-
 // Config defines the configuration for the libp2p network provider.
 type Config struct {
 	Peers []string
 	Port  int
 	Seed  int
+
+	// DataDir is where this node persists state that must survive a
+	// restart, such as peermgr's per-peer connection scores. When empty,
+	// that state is kept in memory only.
+	DataDir string
+
+	// EnabledSecurityProtocols lists the additional transport security
+	// options this node accepts inbound connections over, alongside its
+	// own stake-authenticated handshake. See SecurityTLS and
+	// SecurityQUIC.
+	EnabledSecurityProtocols []SecurityProtocol
 }
 
 type provider struct {
@@ -76,10 +78,26 @@ type provider struct {
 	connectionManager *connectionManager
 }
 
-func (p *provider) ChannelFor(name string) (net.BroadcastChannel, error) {
+// ChannelFor returns the gossipsub-backed broadcast channel for name,
+// registering unmarshaler as the constructor used to decode messages
+// received on it.
+func (p *provider) ChannelFor(
+	name string,
+	unmarshaler func() net.TaggedUnmarshaler,
+) (net.BroadcastChannel, error) {
 	p.channelManagerMutex.Lock()
 	defer p.channelManagerMutex.Unlock()
-	return p.channelManagr.getChannel(name)
+
+	channel, err := p.channelManagr.getChannel(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := channel.RegisterUnmarshaler(unmarshaler); err != nil {
+		return nil, err
+	}
+
+	return channel, nil
 }
 
 func (p *provider) Type() string {
@@ -90,6 +108,9 @@ func (p *provider) ID() net.TransportIdentifier {
 	return networkIdentity(p.identity.id)
 }
 
+// AddrStrings returns every multiaddr this node listens on, including any
+// QUIC listen addrs alongside the usual TCP ones, each suffixed with this
+// node's peer ID.
 func (p *provider) AddrStrings() []string {
 	multiaddrStrings := make([]string, 0, len(p.addrs))
 	for _, multiaddr := range p.addrs {
@@ -155,7 +176,7 @@ func (cm *connectionManager) DisconnectPeer(connectedPeer string) {
 	connections := cm.Network().ConnsToPeer(peer.ID(connectedPeer))
 	for _, connection := range connections {
 		if err := connection.Close(); err != nil {
-			logger.Errorf("failed to disconnect: [%v]", err)
+			logger.Error("failed to disconnect", zap.Error(err))
 		}
 	}
 }
@@ -194,16 +215,27 @@ func Connect(
 		return nil, err
 	}
 
-	host, err := discoverAndListen(ctx, identity, config.Port, stakeMonitor)
+	host, err := discoverAndListen(
+		ctx,
+		identity,
+		config.Port,
+		stakeMonitor,
+		config.EnabledSecurityProtocols,
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	cm, err := newChannelManager(ctx, identity, host)
+	cm, err := newChannelManager(ctx, identity, host, stakeMonitor)
 	if err != nil {
 		return nil, err
 	}
 
+	peerScoreStore, err := newPeerScoreStore(config.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not open peer score store: [%v]", err)
+	}
+
 	router := dht.NewDHT(ctx, host, dssync.MutexWrap(dstore.NewMapDatastore()))
 
 	provider := &provider{
@@ -219,25 +251,46 @@ func Connect(
 		return provider, nil
 	}
 
-	if err := provider.bootstrap(ctx, config.Peers); err != nil {
-		return nil, fmt.Errorf("Failed to bootstrap nodes with err: %v", err)
-	}
-
 	connectionManager := &connectionManager{provider.host}
 	connectionManager.OnConnected(func(peer string) {
-		logger.Infof("connected to peer [%v]", peer)
+		logging.WithPeerID(logger, peer).Info("connected to peer")
 	})
 	connectionManager.OnDisconnected(func(peer string) {
-		logger.Infof("disconnected from peer [%v]", peer)
+		logging.WithPeerID(logger, peer).Info("disconnected from peer")
 	})
 
 	provider.connectionManager = connectionManager
 
-	// Instantiates and starts the connection management background process
+	// Admits and evicts peers based on stake as soon as libp2p identify
+	// completes for them, rather than on a fixed poll.
 	watchtower.NewGuard(
-		ctx, StakeCheckTick, stakeMonitor, provider.connectionManager,
+		ctx,
+		provider.host,
+		stakeMonitor,
+		provider.connectionManager,
+		router.RoutingTable(),
 	)
 
+	bootstrapPeerInfos, err := extractMultiAddrFromPeers(config.Peers)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keeps the node dialed up to a target number of staked operator
+	// peers, re-bootstrapping aggressively when connectivity drops and
+	// pruning the lowest-scoring peers when it is oversubscribed. Scores
+	// persist in peerScoreStore so a restarting node does not re-learn
+	// who the bad actors are.
+	peerManager := peermgr.New(
+		ctx,
+		provider.host,
+		provider.connectionManager,
+		stakeMonitor,
+		peerScoreStore,
+		bootstrapPeerInfos,
+	)
+	peerManager.Start()
+
 	return provider, nil
 }
 
@@ -246,30 +299,22 @@ func discoverAndListen(
 	identity *identity,
 	port int,
 	stakeMonitor chain.StakeMonitor,
+	enabledSecurityProtocols []SecurityProtocol,
 ) (host.Host, error) {
-	var err error
-
 	// Get available network ifaces, for a specific port, as multiaddrs
-	addrs, err := getListenAddrs(port)
+	addrs, err := getListenAddrs(port, enables(enabledSecurityProtocols, SecurityQUIC))
 	if err != nil {
 		return nil, err
 	}
 
-	transport, err := newAuthenticatedTransport(
-		identity.privKey,
-		stakeMonitor,
-	)
+	options, err := securityOptions(identity, stakeMonitor, enabledSecurityProtocols)
 	if err != nil {
-		return nil, fmt.Errorf(
-			"could not create authenticated transport [%v]",
-			err,
-		)
+		return nil, err
 	}
 
-	return libp2p.New(ctx,
+	options = append(options,
 		libp2p.ListenAddrs(addrs...),
 		libp2p.Identity(identity.privKey),
-		libp2p.Security(handshakeID, transport),
 		libp2p.ConnectionManager(
 			connmgr.NewConnManager(
 				DefaultConnMgrLowWater,
@@ -278,44 +323,46 @@ func discoverAndListen(
 			),
 		),
 	)
+
+	return libp2p.New(ctx, options...)
 }
 
-func getListenAddrs(port int) ([]ma.Multiaddr, error) {
+// getListenAddrs returns the multiaddrs this node listens on: a TCP
+// multiaddr for every local interface, plus a QUIC multiaddr for each one
+// too when QUIC is enabled.
+func getListenAddrs(port int, enableQUIC bool) ([]ma.Multiaddr, error) {
 	ia, err := addrutil.InterfaceAddresses()
 	if err != nil {
 		return nil, err
 	}
 	addrs := make([]ma.Multiaddr, 0)
 	for _, addr := range ia {
-		portAddr, err := ma.NewMultiaddr(fmt.Sprintf("/tcp/%d", port))
+		tcpAddr, err := ma.NewMultiaddr(fmt.Sprintf("/tcp/%d", port))
 		if err != nil {
 			return nil, err
 		}
-		addrs = append(addrs, addr.Encapsulate(portAddr))
+		addrs = append(addrs, addr.Encapsulate(tcpAddr))
+
+		if enableQUIC {
+			quicAddr, err := ma.NewMultiaddr(fmt.Sprintf("/udp/%d/quic", port))
+			if err != nil {
+				return nil, err
+			}
+			addrs = append(addrs, addr.Encapsulate(quicAddr))
+		}
 	}
 	return addrs, nil
 }
 
-func (p *provider) bootstrap(ctx context.Context, bootstrapPeers []string) error {
-	peerInfos, err := extractMultiAddrFromPeers(bootstrapPeers)
-	if err != nil {
-		return err
+// newPeerScoreStore opens the on-disk datastore peermgr uses to persist
+// peer scores across restarts. When dataDir is empty, an in-memory store
+// is used instead; peer scores will not survive a restart in that case.
+func newPeerScoreStore(dataDir string) (dstore.Datastore, error) {
+	if dataDir == "" {
+		return dssync.MutexWrap(dstore.NewMapDatastore()), nil
 	}
 
-	bootstraConfig := bootstrap.BootstrapConfigWithPeers(peerInfos)
-
-	// TODO: allow this to be a configurable value
-	bootstraConfig.Period = BootstrapCheckPeriod
-
-	// TODO: use the io.Closer to shutdown the bootstrapper when we build out
-	// a shutdown process.
-	_, err = bootstrap.Bootstrap(
-		p.identity.id,
-		p.host,
-		p.routing,
-		bootstraConfig,
-	)
-	return err
+	return dsleveldb.NewDatastore(dataDir+"/peermgr", nil)
 }
 
 func extractMultiAddrFromPeers(peers []string) ([]peerstore.PeerInfo, error) {
@@ -0,0 +1,148 @@
+package libp2p
+
+import (
+	"fmt"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	coreconnmgr "github.com/libp2p/go-libp2p-core/connmgr"
+	corenetwork "github.com/libp2p/go-libp2p-core/network"
+	peer "github.com/libp2p/go-libp2p-peer"
+	quic "github.com/libp2p/go-libp2p-quic-transport"
+	tls "github.com/libp2p/go-libp2p-tls"
+
+	ma "github.com/multiformats/go-multiaddr"
+	"go.uber.org/zap"
+
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/logging"
+	"github.com/keep-network/keep-core/pkg/net/key"
+)
+
+// SecurityProtocol identifies an additional transport this node can be
+// configured to accept inbound connections over, alongside its own
+// stake-authenticated handshake.
+type SecurityProtocol string
+
+const (
+	// SecurityTLS enables libp2p-tls as an additional security transport
+	// for TCP connections.
+	SecurityTLS SecurityProtocol = "tls"
+	// SecurityQUIC enables the QUIC listen transport, which carries its
+	// own TLS 1.3 based security and therefore needs no separate
+	// libp2p.Security option.
+	SecurityQUIC SecurityProtocol = "quic"
+)
+
+func enables(protocols []SecurityProtocol, protocol SecurityProtocol) bool {
+	for _, enabled := range protocols {
+		if enabled == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// securityOptions builds the libp2p.Security and libp2p.Transport options
+// for discoverAndListen. The project's stake-authenticated handshake is
+// always included; libp2p-tls and QUIC are added on top of it when
+// enabled in protocols.
+func securityOptions(
+	identity *identity,
+	stakeMonitor chain.StakeMonitor,
+	protocols []SecurityProtocol,
+) ([]libp2p.Option, error) {
+	authenticatedTransport, err := newAuthenticatedTransport(identity.privKey, stakeMonitor)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not create authenticated transport [%v]",
+			err,
+		)
+	}
+
+	options := []libp2p.Option{
+		libp2p.Security(handshakeID, authenticatedTransport),
+		libp2p.ConnectionGater(newStakeConnectionGater(stakeMonitor)),
+	}
+
+	if enables(protocols, SecurityTLS) {
+		tlsTransport, err := tls.New(identity.privKey)
+		if err != nil {
+			return nil, fmt.Errorf("could not create TLS transport [%v]", err)
+		}
+		options = append(options, libp2p.Security(tls.ID, tlsTransport))
+	}
+
+	if enables(protocols, SecurityQUIC) {
+		options = append(options, libp2p.Transport(quic.NewTransport))
+	}
+
+	return options, nil
+}
+
+// stakeConnectionGater rejects a connection from a peer that does not meet
+// the minimum stake. The project's own handshake already refuses such a
+// peer as part of SecureInbound/SecureOutbound, but libp2p-tls and QUIC
+// have no hook for that inside their own handshake, so the same check is
+// applied here via InterceptSecured, libp2p's hook for rejecting a
+// connection once its remote identity is known but before it is handed
+// back to the swarm and made available for muxing - a peer gated here
+// never gets the chance to open an application stream.
+type stakeConnectionGater struct {
+	stakeMonitor chain.StakeMonitor
+}
+
+func newStakeConnectionGater(stakeMonitor chain.StakeMonitor) *stakeConnectionGater {
+	return &stakeConnectionGater{stakeMonitor: stakeMonitor}
+}
+
+func (scg *stakeConnectionGater) InterceptSecured(
+	_ corenetwork.Direction,
+	remotePeer peer.ID,
+	_ corenetwork.ConnMultiaddrs,
+) bool {
+	peerLogger := logging.WithPeerID(logger, remotePeer.String())
+
+	publicKey, err := remotePeer.ExtractPublicKey()
+	if err != nil {
+		peerLogger.Warn(
+			"could not extract public key, rejecting connection",
+			zap.Error(err),
+		)
+		return false
+	}
+
+	hasMinimumStake, err := scg.stakeMonitor.HasMinimumStake(
+		key.Libp2pKeyToNetworkKey(publicKey).Address(),
+	)
+	if err != nil || !hasMinimumStake {
+		peerLogger.Warn("peer does not meet minimum stake, rejecting connection")
+		return false
+	}
+
+	return true
+}
+
+// InterceptPeerDial always allows dialing; this node relies on
+// InterceptSecured to reject a peer once its stake can actually be
+// checked, rather than filtering dial targets up front.
+func (scg *stakeConnectionGater) InterceptPeerDial(peer.ID) bool {
+	return true
+}
+
+// InterceptAddrDial always allows dialing any address of a peer this node
+// is already willing to dial.
+func (scg *stakeConnectionGater) InterceptAddrDial(peer.ID, ma.Multiaddr) bool {
+	return true
+}
+
+// InterceptAccept always allows accepting a connection; the stake check
+// cannot run until the remote peer's identity is known post-handshake.
+func (scg *stakeConnectionGater) InterceptAccept(corenetwork.ConnMultiaddrs) bool {
+	return true
+}
+
+// InterceptUpgraded always allows the already-gated connection through;
+// stake was already enforced by InterceptSecured before multiplexing.
+func (scg *stakeConnectionGater) InterceptUpgraded(corenetwork.Conn) (bool, coreconnmgr.DisconnectReason) {
+	return true, 0
+}
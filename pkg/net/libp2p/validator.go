@@ -0,0 +1,116 @@
+package libp2p
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultValidatorQueueSize is the number of envelopes a topic's validator
+// worker pool will buffer before new envelopes are dropped rather than
+// blocking the pubsub read loop.
+const DefaultValidatorQueueSize = 256
+
+// topicValidator is the per-topic check run against every inbound envelope's
+// payload before it is handed to that topic's registered message handlers.
+// It returns true if the envelope should be accepted. It is handed the raw
+// payload, not the envelope itself, so callers outside this package (for
+// example a validator built from a DKG group's MessageFiltering) can
+// construct one without reaching into this package's unexported envelope
+// type.
+type topicValidator func(senderID string, payload []byte) bool
+
+// validationRequest carries a single envelope's payload through a
+// validatorPool; onDone is invoked with the verdict once validate has run,
+// from whichever worker goroutine picks the request up.
+type validationRequest struct {
+	senderID string
+	payload  []byte
+	onDone   func(accepted bool)
+}
+
+// validatorPool runs a topic's validator function across a bounded number
+// of workers so a slow or adversarial validator can only ever stall that
+// topic, never the shared pubsub read loop. Submission is fire-and-forget:
+// submit never blocks the caller waiting on a result, so a slow validator
+// cannot stall the loop that called it either - the worker itself calls
+// onDone once it has a verdict. Envelopes submitted once the queue is full
+// are dropped (onDone is called with false) and counted in droppedCount.
+type validatorPool struct {
+	validate topicValidator
+	queue    chan *validationRequest
+
+	droppedCount uint64
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newValidatorPool starts a validatorPool of workerCount goroutines
+// consuming from a queue of the given size.
+func newValidatorPool(
+	validate topicValidator,
+	workerCount int,
+	queueSize int,
+) *validatorPool {
+	if queueSize <= 0 {
+		queueSize = DefaultValidatorQueueSize
+	}
+
+	pool := &validatorPool{
+		validate: validate,
+		queue:    make(chan *validationRequest, queueSize),
+		stop:     make(chan struct{}),
+	}
+
+	for i := 0; i < workerCount; i++ {
+		pool.wg.Add(1)
+		go pool.worker()
+	}
+
+	return pool
+}
+
+func (vp *validatorPool) worker() {
+	defer vp.wg.Done()
+
+	for {
+		select {
+		case req := <-vp.queue:
+			req.onDone(vp.validate(req.senderID, req.payload))
+		case <-vp.stop:
+			return
+		}
+	}
+}
+
+// submit enqueues payload for validation and returns immediately without
+// waiting for a verdict; onDone is called later, from a worker goroutine,
+// once validation completes. If the queue is full, onDone is called
+// immediately with false instead (and droppedCount is bumped) rather than
+// blocking the caller, which is expected to be the pubsub read loop.
+func (vp *validatorPool) submit(senderID string, payload []byte, onDone func(accepted bool)) {
+	req := &validationRequest{
+		senderID: senderID,
+		payload:  payload,
+		onDone:   onDone,
+	}
+
+	select {
+	case vp.queue <- req:
+	default:
+		atomic.AddUint64(&vp.droppedCount, 1)
+		onDone(false)
+	}
+}
+
+// Dropped returns the number of envelopes dropped so far because the
+// validator queue was full; it is exposed so it can be wired into a
+// metrics reporter.
+func (vp *validatorPool) Dropped() uint64 {
+	return atomic.LoadUint64(&vp.droppedCount)
+}
+
+func (vp *validatorPool) close() {
+	close(vp.stop)
+	vp.wg.Wait()
+}
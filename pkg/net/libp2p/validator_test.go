@@ -0,0 +1,140 @@
+package libp2p
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestValidatorPoolSubmitDoesNotBlockCaller verifies that submit hands the
+// request off to a worker and returns immediately, instead of waiting for
+// validate to run - a slow validator must not be able to stall whatever
+// loop is calling submit.
+func TestValidatorPoolSubmitDoesNotBlockCaller(t *testing.T) {
+	release := make(chan struct{})
+	validate := func(senderID string, payload []byte) bool {
+		<-release
+		return true
+	}
+
+	pool := newValidatorPool(validate, 1, DefaultValidatorQueueSize)
+	defer pool.close()
+
+	done := make(chan struct{})
+	go func() {
+		pool.submit("sender", []byte("payload"), func(accepted bool) {})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("submit blocked on a validator that had not yet returned")
+	}
+
+	close(release)
+}
+
+// TestValidatorPoolSubmitCallsOnDoneWithVerdict verifies that onDone is
+// eventually invoked with validate's verdict once a worker has processed
+// the request.
+func TestValidatorPoolSubmitCallsOnDoneWithVerdict(t *testing.T) {
+	validate := func(senderID string, payload []byte) bool {
+		return senderID == "accepted-sender"
+	}
+
+	pool := newValidatorPool(validate, 1, DefaultValidatorQueueSize)
+	defer pool.close()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	var acceptedResult, rejectedResult bool
+	pool.submit("accepted-sender", nil, func(accepted bool) {
+		acceptedResult = accepted
+		wg.Done()
+	})
+	pool.submit("other-sender", nil, func(accepted bool) {
+		rejectedResult = accepted
+		wg.Done()
+	})
+
+	waitWithTimeout(t, &wg, time.Second)
+
+	if !acceptedResult {
+		t.Error("expected accepted-sender's request to be accepted")
+	}
+	if rejectedResult {
+		t.Error("expected other-sender's request to be rejected")
+	}
+}
+
+// TestValidatorPoolSubmitDropsWhenQueueFull verifies that once the queue is
+// full, submit calls onDone with false immediately and counts the drop,
+// rather than blocking until a worker frees up space.
+func TestValidatorPoolSubmitDropsWhenQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	validate := func(senderID string, payload []byte) bool {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return true
+	}
+
+	const queueSize = 2
+	pool := newValidatorPool(validate, 1, queueSize)
+	defer func() {
+		close(release)
+		pool.close()
+	}()
+
+	// Fill the single worker and the queue behind it so the next submit
+	// has nowhere to go. The worker must have taken the first request off
+	// the queue before the queue is filled, or one of these would be dropped.
+	pool.submit("sender", nil, func(accepted bool) {})
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not pick up the first request")
+	}
+	for i := 0; i < queueSize; i++ {
+		pool.submit("sender", nil, func(accepted bool) {})
+	}
+
+	dropped := make(chan bool, 1)
+	pool.submit("sender", nil, func(accepted bool) {
+		dropped <- accepted
+	})
+
+	select {
+	case accepted := <-dropped:
+		if accepted {
+			t.Error("expected the over-capacity request to be rejected")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("submit blocked instead of dropping the over-capacity request")
+	}
+
+	if got := pool.Dropped(); got != 1 {
+		t.Errorf("Dropped() = %d, want 1", got)
+	}
+}
+
+func waitWithTimeout(t *testing.T, wg *sync.WaitGroup, timeout time.Duration) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatal("timed out waiting for validator pool callbacks")
+	}
+}
@@ -0,0 +1,440 @@
+// Package peermgr maintains a target number of connected, staked operator
+// peers for this node. It scores candidate peers by uptime, DKG-phase
+// liveness, and message latency, and persists those scores across
+// restarts so a restarting node does not re-learn who the bad actors are.
+package peermgr
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	dsquery "github.com/ipfs/go-datastore/query"
+	host "github.com/libp2p/go-libp2p-host"
+	peer "github.com/libp2p/go-libp2p-peer"
+	peerstore "github.com/libp2p/go-libp2p-peerstore"
+
+	"go.uber.org/zap"
+
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/logging"
+	"github.com/keep-network/keep-core/pkg/net"
+)
+
+var logger = logging.Logger("keep-net-peermgr")
+
+const (
+	// DefaultTargetPeerCount is how many connected, staked operator peers
+	// Manager tries to maintain.
+	DefaultTargetPeerCount = 50
+	// DefaultLowWater is the connected-peer count below which Manager
+	// aggressively redials from the DHT and known-operator set.
+	DefaultLowWater = 20
+	// DefaultHighWater is the connected-peer count above which Manager
+	// prunes the lowest-scoring non-group peers.
+	DefaultHighWater = 80
+	// DefaultDialLoopPeriod is how often Manager checks whether it needs
+	// to dial more peers or prune excess ones.
+	DefaultDialLoopPeriod = 30 * time.Second
+)
+
+// scoring weights; liveness during DKG rounds matters most, followed by
+// uptime, with raw message latency a tie-breaker.
+const (
+	uptimeWeight   = 0.3
+	livenessWeight = 0.5
+	latencyWeight  = 0.2
+)
+
+// record is a single peer's persisted standing, keyed by peer ID in the
+// backing datastore.
+type record struct {
+	FirstSeenUnix  int64
+	ActiveRounds   int
+	InactiveRounds int
+	LatencyEWMAMs  float64
+}
+
+// maxUptimeHours caps how much credit a peer's raw connection age can earn
+// before being normalized below; without a cap, a peer connected for weeks
+// accumulates enough raw hours to dominate the weighted score regardless
+// of liveness, the opposite of this package's stated scoring priority.
+const maxUptimeHours = 7 * 24
+
+func (r *record) score() float64 {
+	total := r.ActiveRounds + r.InactiveRounds
+	liveness := 1.0
+	if total > 0 {
+		liveness = float64(r.ActiveRounds) / float64(total)
+	}
+
+	uptimeHours := time.Since(time.Unix(r.FirstSeenUnix, 0)).Hours()
+	if uptimeHours > maxUptimeHours {
+		uptimeHours = maxUptimeHours
+	}
+	uptime := uptimeHours / maxUptimeHours * 100
+
+	latency := 0.0
+	if r.LatencyEWMAMs > 0 {
+		latency = 1000.0 / r.LatencyEWMAMs
+		if latency > 100 {
+			latency = 100
+		}
+	}
+
+	return uptimeWeight*uptime + livenessWeight*liveness*100 + latencyWeight*latency
+}
+
+// datastoreKey is the persisted key for a peer's record.
+func datastoreKey(p peer.ID) ds.Key {
+	return ds.NewKey("/peermgr/score/" + p.String())
+}
+
+// Manager maintains a target number of connected, staked operator peers
+// for this node. It continuously dials from the DHT and known-operator
+// set to keep the target satisfied; when the connection count drops
+// below lowWater it redials aggressively, and when it rises above
+// highWater it prunes the lowest-scoring peers that are not part of this
+// node's current DKG group.
+type Manager struct {
+	ctx context.Context
+
+	host              host.Host
+	connectionManager net.ConnectionManager
+	stakeMonitor      chain.StakeMonitor
+	store             ds.Datastore
+	bootstrapPeers    []peerstore.PeerInfo
+
+	targetPeerCount int
+	lowWater        int
+	highWater       int
+	dialLoopPeriod  time.Duration
+
+	recordsMutex sync.Mutex
+	records      map[peer.ID]*record
+
+	protectedMutex sync.Mutex
+	protectedPeers map[peer.ID]bool
+}
+
+// New creates a Manager backed by store for persisting peer scores across
+// restarts, and loads any scores already recorded there.
+func New(
+	ctx context.Context,
+	h host.Host,
+	connectionManager net.ConnectionManager,
+	stakeMonitor chain.StakeMonitor,
+	store ds.Datastore,
+	bootstrapPeers []peerstore.PeerInfo,
+) *Manager {
+	m := &Manager{
+		ctx:               ctx,
+		host:              h,
+		connectionManager: connectionManager,
+		stakeMonitor:      stakeMonitor,
+		store:             store,
+		bootstrapPeers:    bootstrapPeers,
+		targetPeerCount:   DefaultTargetPeerCount,
+		lowWater:          DefaultLowWater,
+		highWater:         DefaultHighWater,
+		dialLoopPeriod:    DefaultDialLoopPeriod,
+		records:           make(map[peer.ID]*record),
+		protectedPeers:    make(map[peer.ID]bool),
+	}
+
+	m.loadRecords()
+
+	return m
+}
+
+// ProtectGroupPeer exempts remotePeer from pruning, even if its score is
+// low, for as long as it remains a member of this node's current DKG
+// group - losing it mid-round would stall the group rather than just
+// losing a low-value connection.
+func (m *Manager) ProtectGroupPeer(remotePeer peer.ID) {
+	m.protectedMutex.Lock()
+	defer m.protectedMutex.Unlock()
+	m.protectedPeers[remotePeer] = true
+}
+
+// UnprotectGroupPeer lifts the pruning exemption granted by
+// ProtectGroupPeer, once remotePeer's group has completed or it has left
+// the group.
+func (m *Manager) UnprotectGroupPeer(remotePeer peer.ID) {
+	m.protectedMutex.Lock()
+	defer m.protectedMutex.Unlock()
+	delete(m.protectedPeers, remotePeer)
+}
+
+func (m *Manager) isProtected(remotePeer peer.ID) bool {
+	m.protectedMutex.Lock()
+	defer m.protectedMutex.Unlock()
+	return m.protectedPeers[remotePeer]
+}
+
+// Start begins the background dial/prune loop. It returns immediately;
+// the loop runs until its context is done.
+func (m *Manager) Start() {
+	go m.dialLoop()
+}
+
+func (m *Manager) dialLoop() {
+	ticker := time.NewTicker(m.dialLoopPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.reconcile()
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// reconcile dials more peers if the number of connected staked operators
+// sits below lowWater (aggressively) or below targetPeerCount (steady
+// state), or prunes the lowest-scoring non-group peers if total
+// connectivity has risen above highWater.
+func (m *Manager) reconcile() {
+	connected := m.connectionManager.ConnectedPeers()
+	stakedCount := len(m.stakedPeers(connected))
+
+	switch {
+	case stakedCount < m.lowWater:
+		logger.Info(
+			"staked peer count below low water mark, redialing aggressively",
+			zap.Int("staked_connected", stakedCount),
+			zap.Int("low_water", m.lowWater),
+		)
+		m.dialToTarget()
+	case stakedCount < m.targetPeerCount:
+		logger.Info(
+			"staked peer count below target, dialing to top up",
+			zap.Int("staked_connected", stakedCount),
+			zap.Int("target", m.targetPeerCount),
+		)
+		m.dialToTarget()
+	case len(connected) > m.highWater:
+		logger.Info(
+			"connected peer count above high water mark, pruning",
+			zap.Int("connected", len(connected)),
+			zap.Int("high_water", m.highWater),
+		)
+		m.pruneToTarget(connected)
+	}
+}
+
+// stakedPeers filters connected down to the operators that currently meet
+// the minimum stake, so target/low/high-water decisions are made against
+// staked operator peers rather than any libp2p connection.
+func (m *Manager) stakedPeers(connected []string) []string {
+	staked := make([]string, 0, len(connected))
+	for _, connectedPeer := range connected {
+		peerID, err := peer.IDB58Decode(connectedPeer)
+		if err != nil {
+			continue
+		}
+		if m.isStakedOperator(peerID) {
+			staked = append(staked, connectedPeer)
+		}
+	}
+	return staked
+}
+
+// isStakedOperator resolves candidate's operator key through the
+// connection manager and checks it against the stake monitor.
+func (m *Manager) isStakedOperator(candidate peer.ID) bool {
+	publicKey, err := m.connectionManager.GetPeerPublicKey(candidate.String())
+	if err != nil {
+		return false
+	}
+
+	hasMinimumStake, err := m.stakeMonitor.HasMinimumStake(publicKey.Address())
+	return err == nil && hasMinimumStake
+}
+
+// dialToTarget dials from the DHT-discovered peer set and the configured
+// bootstrap peers until targetPeerCount staked operator peers are
+// connected or there are no further candidates to try. A candidate that
+// connects but does not meet the minimum stake does not count against
+// targetPeerCount; watchtower.Guard is responsible for disconnecting it.
+func (m *Manager) dialToTarget() {
+	candidates := m.host.Peerstore().Peers()
+
+	for _, bootstrapPeer := range m.bootstrapPeers {
+		m.host.Peerstore().AddAddrs(
+			bootstrapPeer.ID,
+			bootstrapPeer.Addrs,
+			peerstore.PermanentAddrTTL,
+		)
+		candidates = append(candidates, bootstrapPeer.ID)
+	}
+
+	connected := make(map[peer.ID]bool)
+	for _, connectedPeer := range m.connectionManager.ConnectedPeers() {
+		connected[peer.ID(connectedPeer)] = true
+	}
+
+	for _, candidate := range candidates {
+		if candidate == m.host.ID() || connected[candidate] {
+			continue
+		}
+
+		if len(m.stakedPeers(m.connectionManager.ConnectedPeers())) >= m.targetPeerCount {
+			return
+		}
+
+		if err := m.host.Connect(m.ctx, m.host.Peerstore().PeerInfo(candidate)); err != nil {
+			logging.WithPeerID(logger, candidate.String()).Warn(
+				"failed to dial candidate peer",
+				zap.Error(err),
+			)
+			continue
+		}
+
+		m.touch(candidate)
+	}
+}
+
+// pruneToTarget disconnects the lowest-scoring peers that are not part of
+// the current DKG group until the connected count is back at
+// targetPeerCount. A connected peer that no longer meets the minimum
+// stake is always scored below any staked operator, so it is pruned
+// first.
+func (m *Manager) pruneToTarget(connected []string) {
+	type scoredPeer struct {
+		id    peer.ID
+		score float64
+	}
+
+	m.recordsMutex.Lock()
+	scored := make([]scoredPeer, 0, len(connected))
+	for _, connectedPeer := range connected {
+		p, err := peer.IDB58Decode(connectedPeer)
+		if err != nil {
+			continue
+		}
+
+		if m.isProtected(p) {
+			continue
+		}
+
+		score := -1.0
+		if m.isStakedOperator(p) {
+			score = 0.0
+			if rec, exists := m.records[p]; exists {
+				score = rec.score()
+			}
+		}
+
+		scored = append(scored, scoredPeer{id: p, score: score})
+	}
+	m.recordsMutex.Unlock()
+
+	sort.Slice(scored, func(i, j int) bool {
+		return scored[i].score < scored[j].score
+	})
+
+	excess := len(connected) - m.targetPeerCount
+	for i := 0; i < excess && i < len(scored); i++ {
+		m.connectionManager.DisconnectPeer(scored[i].id.String())
+	}
+}
+
+// MarkActive records that remotePeer was observed behaving correctly in
+// the current DKG phase, improving its liveness score.
+func (m *Manager) MarkActive(remotePeer peer.ID) {
+	m.updateRecord(remotePeer, func(r *record) {
+		r.ActiveRounds++
+	})
+}
+
+// MarkInactive records that remotePeer was filtered out as inactive or
+// disqualified in the current DKG phase, worsening its liveness score.
+func (m *Manager) MarkInactive(remotePeer peer.ID) {
+	m.updateRecord(remotePeer, func(r *record) {
+		r.InactiveRounds++
+	})
+}
+
+// ObserveLatency folds a message round-trip latency sample into
+// remotePeer's running latency average.
+func (m *Manager) ObserveLatency(remotePeer peer.ID, latency time.Duration) {
+	m.updateRecord(remotePeer, func(r *record) {
+		sampleMs := float64(latency.Milliseconds())
+		if r.LatencyEWMAMs == 0 {
+			r.LatencyEWMAMs = sampleMs
+			return
+		}
+		const alpha = 0.2
+		r.LatencyEWMAMs = alpha*sampleMs + (1-alpha)*r.LatencyEWMAMs
+	})
+}
+
+func (m *Manager) touch(remotePeer peer.ID) {
+	m.updateRecord(remotePeer, func(r *record) {})
+}
+
+func (m *Manager) updateRecord(remotePeer peer.ID, mutate func(*record)) {
+	m.recordsMutex.Lock()
+	rec, exists := m.records[remotePeer]
+	if !exists {
+		rec = &record{FirstSeenUnix: time.Now().Unix()}
+		m.records[remotePeer] = rec
+	}
+	mutate(rec)
+	m.recordsMutex.Unlock()
+
+	m.persistRecord(remotePeer, rec)
+}
+
+func (m *Manager) persistRecord(remotePeer peer.ID, rec *record) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		logging.WithPeerID(logger, remotePeer.String()).Warn(
+			"failed to marshal peer record", zap.Error(err),
+		)
+		return
+	}
+
+	if err := m.store.Put(datastoreKey(remotePeer), data); err != nil {
+		logging.WithPeerID(logger, remotePeer.String()).Warn(
+			"failed to persist peer record", zap.Error(err),
+		)
+	}
+}
+
+// loadRecords restores every previously persisted peer record from the
+// datastore, so a restarting node does not re-learn who the bad actors
+// are.
+func (m *Manager) loadRecords() {
+	results, err := m.store.Query(dsquery.Query{Prefix: "/peermgr/score"})
+	if err != nil {
+		logger.Warn("failed to query persisted peer records", zap.Error(err))
+		return
+	}
+	defer results.Close()
+
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			continue
+		}
+
+		rec := &record{}
+		if err := json.Unmarshal(entry.Value, rec); err != nil {
+			continue
+		}
+
+		remotePeerID := ds.RawKey(entry.Key).Name()
+		remotePeer, err := peer.IDB58Decode(remotePeerID)
+		if err != nil {
+			continue
+		}
+
+		m.records[remotePeer] = rec
+	}
+}
@@ -0,0 +1,64 @@
+package peermgr
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRecordScoreCapsUptimeCredit verifies that a peer connected well past
+// maxUptimeHours earns no more uptime credit than one that just reached
+// the cap, so raw connection age alone cannot dominate a peer with worse
+// liveness.
+func TestRecordScoreCapsUptimeCredit(t *testing.T) {
+	now := time.Now()
+
+	atCap := &record{
+		FirstSeenUnix: now.Add(-maxUptimeHours * time.Hour).Unix(),
+	}
+	wayPastCap := &record{
+		FirstSeenUnix: now.Add(-10 * maxUptimeHours * time.Hour).Unix(),
+	}
+
+	if got, want := wayPastCap.score(), atCap.score(); got != want {
+		t.Errorf("score for a peer far past the uptime cap = %v, want %v (same as at-cap peer)", got, want)
+	}
+}
+
+// TestRecordScoreLivenessDominatesLongUptime verifies the package's stated
+// scoring priority - liveness first, then uptime - by checking that a
+// recently-connected, perfectly live peer outscores a long-connected peer
+// with poor liveness.
+func TestRecordScoreLivenessDominatesLongUptime(t *testing.T) {
+	now := time.Now()
+
+	recentlyConnectedButLive := &record{
+		FirstSeenUnix: now.Add(-1 * time.Hour).Unix(),
+		ActiveRounds:  10,
+	}
+	longConnectedButFlaky := &record{
+		FirstSeenUnix:  now.Add(-maxUptimeHours * time.Hour).Unix(),
+		ActiveRounds:   1,
+		InactiveRounds: 9,
+	}
+
+	if recentlyConnectedButLive.score() <= longConnectedButFlaky.score() {
+		t.Errorf(
+			"recently-connected, fully-live peer scored %v, expected higher than long-connected, flaky peer's %v",
+			recentlyConnectedButLive.score(),
+			longConnectedButFlaky.score(),
+		)
+	}
+}
+
+// TestRecordScoreZeroUptimeIsZeroCredit verifies a brand-new peer earns no
+// uptime credit yet, rather than a negative or otherwise undefined value.
+func TestRecordScoreZeroUptimeIsZeroCredit(t *testing.T) {
+	rec := &record{FirstSeenUnix: time.Now().Unix(), ActiveRounds: 1}
+
+	minScore := livenessWeight * 100
+	maxScore := minScore + 0.5 // allow for the few milliseconds of elapsed uptime
+
+	if got := rec.score(); got < minScore || got > maxScore {
+		t.Errorf("score() = %v, want within [%v, %v] for a brand-new, fully-live peer", got, minScore, maxScore)
+	}
+}
@@ -0,0 +1,187 @@
+// Package watchtower admits and evicts libp2p peers from this node's DHT
+// routing table based on their on-chain stake, and closes the connections
+// of peers that do not meet it. It does not add peers to or remove them
+// from the pubsub mesh itself.
+package watchtower
+
+import (
+	"context"
+	"sync"
+
+	event "github.com/libp2p/go-libp2p-core/event"
+	host "github.com/libp2p/go-libp2p-host"
+	peer "github.com/libp2p/go-libp2p-peer"
+
+	"go.uber.org/zap"
+
+	"github.com/keep-network/keep-core/pkg/chain"
+	"github.com/keep-network/keep-core/pkg/logging"
+	"github.com/keep-network/keep-core/pkg/net"
+)
+
+var logger = logging.Logger("keep-net-watchtower")
+
+// RoutingTable is the subset of a DHT routing table Guard needs in order to
+// admit or withhold a peer from it; it is satisfied by the routing table
+// returned from (*dht.IpfsDHT).RoutingTable().
+type RoutingTable interface {
+	Update(p peer.ID) error
+	Remove(p peer.ID) error
+}
+
+// DisconnectReason identifies why Guard closed a connection to a peer, so
+// the reason can be surfaced in logs.
+type DisconnectReason int
+
+const (
+	// ReasonInsufficientStake is used when a peer does not meet the
+	// minimum stake required to participate in the network.
+	ReasonInsufficientStake DisconnectReason = iota
+	// ReasonStakeLost is used when a previously-admitted peer's stake
+	// drops below the minimum during the session.
+	ReasonStakeLost
+)
+
+func (r DisconnectReason) String() string {
+	switch r {
+	case ReasonInsufficientStake:
+		return "insufficient stake"
+	case ReasonStakeLost:
+		return "stake lost mid-session"
+	default:
+		return "unknown disconnect reason"
+	}
+}
+
+// Guard admits a peer into the routing table only once libp2p identify has
+// completed for it and its operator key meets the minimum stake, and evicts
+// it from the routing table and disconnects it immediately if it is found
+// not to, or if it later loses its stake. Pubsub mesh membership is not
+// managed by Guard. This replaces the previous fixed-tick poll, which
+// left a window where an unstaked peer was briefly usable.
+type Guard struct {
+	ctx context.Context
+
+	host              host.Host
+	stakeMonitor      chain.StakeMonitor
+	connectionManager net.ConnectionManager
+	routingTable      RoutingTable
+
+	admittedMutex sync.Mutex
+	admitted      map[string]peer.ID
+}
+
+// NewGuard creates a Guard that subscribes to h's identify-completion
+// events and stakeMonitor's stake-change events, and starts enforcing
+// stake admission immediately.
+func NewGuard(
+	ctx context.Context,
+	h host.Host,
+	stakeMonitor chain.StakeMonitor,
+	connectionManager net.ConnectionManager,
+	routingTable RoutingTable,
+) *Guard {
+	guard := &Guard{
+		ctx:               ctx,
+		host:              h,
+		stakeMonitor:      stakeMonitor,
+		connectionManager: connectionManager,
+		routingTable:      routingTable,
+		admitted:          make(map[string]peer.ID),
+	}
+
+	go guard.watchIdentifyEvents()
+	guard.watchStakeChanges()
+
+	return guard
+}
+
+// watchIdentifyEvents subscribes to EvtPeerIdentificationCompleted on the
+// host's event bus and runs admission for every peer it completes for.
+func (g *Guard) watchIdentifyEvents() {
+	subscription, err := g.host.EventBus().Subscribe(
+		new(event.EvtPeerIdentificationCompleted),
+	)
+	if err != nil {
+		logger.Error("could not subscribe to identify events", zap.Error(err))
+		return
+	}
+	defer subscription.Close()
+
+	for {
+		select {
+		case evt, ok := <-subscription.Out():
+			if !ok {
+				return
+			}
+
+			identifyEvent := evt.(event.EvtPeerIdentificationCompleted)
+			g.admitOrReject(identifyEvent.Peer)
+		case <-g.ctx.Done():
+			return
+		}
+	}
+}
+
+// admitOrReject looks up remotePeer's operator key and stake, and either
+// admits it into the routing table or disconnects it.
+func (g *Guard) admitOrReject(remotePeer peer.ID) {
+	peerLogger := logging.WithPeerID(logger, remotePeer.String())
+
+	publicKey, err := g.connectionManager.GetPeerPublicKey(remotePeer.String())
+	if err != nil {
+		peerLogger.Warn("could not resolve operator key for peer", zap.Error(err))
+		g.reject(remotePeer, ReasonInsufficientStake)
+		return
+	}
+
+	operatorAddress := publicKey.Address()
+
+	hasMinimumStake, err := g.stakeMonitor.HasMinimumStake(operatorAddress)
+	if err != nil || !hasMinimumStake {
+		g.reject(remotePeer, ReasonInsufficientStake)
+		return
+	}
+
+	g.admittedMutex.Lock()
+	g.admitted[operatorAddress] = remotePeer
+	g.admittedMutex.Unlock()
+
+	if err := g.routingTable.Update(remotePeer); err != nil {
+		peerLogger.Warn("could not admit peer into routing table", zap.Error(err))
+	}
+}
+
+// reject evicts remotePeer from the routing table, if present, and closes
+// its connection.
+func (g *Guard) reject(remotePeer peer.ID, reason DisconnectReason) {
+	logging.WithPeerID(logger, remotePeer.String()).Info(
+		"disconnecting peer",
+		zap.String("reason", reason.String()),
+	)
+
+	_ = g.routingTable.Remove(remotePeer)
+	g.connectionManager.DisconnectPeer(remotePeer.String())
+}
+
+// watchStakeChanges registers a callback with the stake monitor so that a
+// peer who loses its stake mid-session is evicted as soon as the change is
+// observed on-chain, instead of waiting for a future poll.
+func (g *Guard) watchStakeChanges() {
+	g.stakeMonitor.OnStakeChanged(func(operatorAddress string, hasMinimumStake bool) {
+		if hasMinimumStake {
+			return
+		}
+
+		g.admittedMutex.Lock()
+		remotePeer, wasAdmitted := g.admitted[operatorAddress]
+		delete(g.admitted, operatorAddress)
+		g.admittedMutex.Unlock()
+
+		if !wasAdmitted {
+			return
+		}
+
+		g.reject(remotePeer, ReasonStakeLost)
+	})
+}